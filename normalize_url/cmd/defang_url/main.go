@@ -0,0 +1,105 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"un/pkg/url"
+)
+
+func init() {
+	log.SetFlags(0)
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		_, _ = fmt.Fprintln(w, "defang_url defangs URLs so they won't auto-link when shared in reports.")
+		_, _ = fmt.Fprintln(w, "Input must be urls line by line (from file or STDIN, read Usage section)")
+		_, _ = fmt.Fprintln(w, "")
+		_, _ = fmt.Fprintln(w, "Usage:")
+		_, _ = fmt.Fprintf(w, "  defang_url [-f FILENAME] [-o FILENAME] [-style STYLE]\n")
+		_, _ = fmt.Fprintln(w, "")
+		_, _ = fmt.Fprintln(w, "Options:")
+		flag.PrintDefaults()
+		_, _ = fmt.Fprintln(w, "")
+		_, _ = fmt.Fprintln(w, "Styles: brackets (http[.]com), parens (http(.)com), dot (http[dot]com)")
+		_, _ = fmt.Fprintln(w, "")
+		_, _ = fmt.Fprintln(w, "Examples:")
+		_, _ = fmt.Fprintf(w, "  defang_url -f urls.txt -o defanged_urls.txt\n\n")
+		_, _ = fmt.Fprintf(w, "  cat urls.txt | defang_url -style parens > defanged_urls.txt\n\n")
+	}
+}
+
+func main() {
+	var iFile string
+	var oFile string
+	var style string
+
+	flag.StringVar(&iFile, "f", "", "Input filename (STDIN if omitted)")
+	flag.StringVar(&oFile, "o", "", "Output filename (STDOUT if omitted)")
+	flag.StringVar(&style, "style", "brackets", "Defang style: brackets, parens, dot")
+	flag.Parse()
+
+	ds, err := parseStyle(style)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in, err := openFile(iFile, os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer in.Close()
+
+	out, err := openFile(oFile, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Split(bufio.ScanLines)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(out, "%s\n", url.Defang(line, ds)); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func parseStyle(s string) (url.DefangStyle, error) {
+	switch s {
+	case "brackets":
+		return url.DefangBrackets, nil
+	case "parens":
+		return url.DefangParens, nil
+	case "dot":
+		return url.DefangDot, nil
+	default:
+		return 0, fmt.Errorf("unknown defang style: %s", s)
+	}
+}
+
+func openFile(fname string, def *os.File) (*os.File, error) {
+	if fname != "" {
+		return os.Open(fname)
+	}
+	return def, nil
+}
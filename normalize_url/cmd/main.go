@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
+	"sync"
+	"un/pkg/match"
 	"un/pkg/url"
 )
 
@@ -29,7 +32,7 @@ func init() {
 		_, _ = fmt.Fprintln(w, "normalize_url normalizes URLs. Input must be urls line by line (from file or STDIN, read Usage section)")
 		_, _ = fmt.Fprintln(w, "")
 		_, _ = fmt.Fprintln(w, "Usage:")
-		_, _ = fmt.Fprintf(w, "  normalize_url [-f FILENAME] [-o FILENAME] [-s]\n")
+		_, _ = fmt.Fprintf(w, "  normalize_url [-f FILENAME] [-o FILENAME] [-s] [-e] [-refang] [-j N] [-block FILE] [-allow FILE] [-base URL]\n")
 		_, _ = fmt.Fprintln(w, "")
 		_, _ = fmt.Fprintln(w, "Options:")
 		flag.PrintDefaults()
@@ -38,7 +41,13 @@ func init() {
 		_, _ = fmt.Fprintf(w, "  normalize_url -f urls.txt -o normalized_urls.txt\n\n")
 		_, _ = fmt.Fprintf(w, "  cat urls.txt | normalize_url > normalized_urls.txt\n\n")
 		_, _ = fmt.Fprintf(w, "  # Strict mode enabled. Any parsing error stops processing\n")
-		_, _ = fmt.Fprintf(w, "  normalize_url -f urls.txt -o normalized_urls.txt -s\n")
+		_, _ = fmt.Fprintf(w, "  normalize_url -f urls.txt -o normalized_urls.txt -s\n\n")
+		_, _ = fmt.Fprintf(w, "  # Normalize with 16 concurrent workers\n")
+		_, _ = fmt.Fprintf(w, "  normalize_url -f urls.txt -o normalized_urls.txt -j 16\n\n")
+		_, _ = fmt.Fprintf(w, "  # Drop blocklisted URLs, unless they're also allowlisted\n")
+		_, _ = fmt.Fprintf(w, "  normalize_url -f urls.txt -block block.txt -allow allow.txt\n\n")
+		_, _ = fmt.Fprintf(w, "  # Resolve relative links scraped from a page against its URL\n")
+		_, _ = fmt.Fprintf(w, "  normalize_url -f links.txt -base https://example.com/articles/\n")
 	}
 }
 
@@ -46,12 +55,43 @@ func main() {
 	var iFile string
 	var oFile string
 	var strictMode bool
+	var emitETLD1 bool
+	var emitRefang bool
+	var workers int
+	var blockFile string
+	var allowFile string
+	var baseURL string
 
 	flag.StringVar(&iFile, "f", "", "Input filename (STDIN if omitted)")
 	flag.StringVar(&oFile, "o", "", "Output filename (STDOUT if omitted)")
 	flag.BoolVar(&strictMode, "s", false, "Strict mode. Stop processing after the first error.")
+	flag.BoolVar(&emitETLD1, "e", false, "Emit the registrable domain (eTLD+1) alongside the normalized URL.")
+	flag.BoolVar(&emitRefang, "refang", false, "Emit the refanged (un-defanged) raw input alongside the normalized URL.")
+	flag.IntVar(&workers, "j", runtime.NumCPU(), "Number of concurrent normalization workers.")
+	flag.StringVar(&blockFile, "block", "", "Path to a blocklist file; matching lines are dropped unless also allowlisted.")
+	flag.StringVar(&allowFile, "allow", "", "Path to an allowlist file; blocklisted lines that also match it are kept and annotated instead of dropped.")
+	flag.StringVar(&baseURL, "base", "", "Absolute base URL to resolve relative inputs against before normalizing.")
 	flag.Parse()
 
+	if workers < 1 {
+		workers = 1
+	}
+
+	opts := workerOptions{emitETLD1: emitETLD1, emitRefang: emitRefang, baseURL: baseURL}
+
+	if blockFile != "" {
+		opts.blockMatcher = match.New()
+		if err := opts.blockMatcher.LoadFile(blockFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if allowFile != "" {
+		opts.allowMatcher = match.New()
+		if err := opts.allowMatcher.LoadFile(allowFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	in, err := openFile(iFile, os.Stdin)
 	if err != nil {
 		log.Fatal(err)
@@ -65,45 +105,189 @@ func main() {
 	defer out.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := lines(ctx, in)
+	results := process(ctx, jobs, workers, opts)
+
+	succeeded, failed := writeOrdered(cancel, results, out, iFile, strictMode)
+
+	log.Printf("normalized: %d; failed: %d", succeeded, failed)
+}
+
+// workerOptions configures how normalizeWorker renders and filters each line.
+type workerOptions struct {
+	emitETLD1    bool
+	emitRefang   bool
+	baseURL      string
+	blockMatcher *match.Matcher
+	allowMatcher *match.Matcher
+}
+
+type Line struct {
+	Url string
+	Pos int
+}
+
+func (l Line) Empty() bool {
+	return l.Url == ""
+}
+
+// result is a single job's outcome tagged with its input sequence number, so
+// results arriving out of order across workers can be reassembled in order.
+type result struct {
+	pos  int
+	raw  string
+	out  string
+	err  error
+	skip bool
+}
+
+// process fans a Line out to workers normalization workers and fans their
+// results back in on a single channel, closing it once every worker is done.
+func process(ctx context.Context, jobs <-chan Line, workers int, opts workerOptions) <-chan result {
+	results := make(chan result, workers*2)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			normalizeWorker(ctx, jobs, results, opts)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func normalizeWorker(ctx context.Context, jobs <-chan Line, results chan<- result, opts workerOptions) {
+	for line := range jobs {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
 
-	succeeded := 0
-	failed := 0
-	for line := range lines(ctx, in) {
 		if line.Empty() {
+			results <- result{pos: line.Pos, skip: true}
 			continue
 		}
 
-		u, err := url.NormalizeURL(line.Url)
+		r := result{pos: line.Pos, raw: line.Url}
+
+		u, hp, err := normalize(line.Url, opts)
 		if err != nil {
-			failed++
-			log.Println(fmt.Sprintf("parse error at \"%s:%d\": %s", iFile, line.Pos, line.Url))
-			if strictMode {
-				cancel()
-				break
-			}
+			r.err = err
+			results <- r
 			continue
 		}
 
-		_, err = out.WriteString(fmt.Sprintf("%s\n", u))
-		if err != nil {
-			cancel()
-			log.Println(err)
-			break
+		annotation := ""
+		if opts.blockMatcher != nil {
+			if _, blocked := opts.blockMatcher.Match(u); blocked {
+				if rule, allowed := matchAllow(opts.allowMatcher, u); allowed {
+					annotation = "allowed:" + rule.Pattern
+				} else {
+					results <- result{pos: line.Pos, skip: true}
+					continue
+				}
+			}
+		}
+
+		outLine := u
+		if opts.emitETLD1 {
+			outLine = fmt.Sprintf("%s\t%s", outLine, hp.RegistrableDomain)
 		}
+		if opts.emitRefang {
+			outLine = fmt.Sprintf("%s\t%s", outLine, url.Refang(line.Url))
+		}
+		if annotation != "" {
+			outLine = fmt.Sprintf("%s\t%s", outLine, annotation)
+		}
+		r.out = outLine
 
-		succeeded++
+		results <- r
 	}
+}
 
-	log.Printf("normalized: %d; failed: %d", succeeded, failed)
+// normalize dispatches to NormalizeURLWithBase when a base URL is
+// configured, resolving relative inputs before normalizing them; otherwise
+// it normalizes raw directly.
+func normalize(raw string, opts workerOptions) (string, url.HostPort, error) {
+	if opts.baseURL == "" {
+		return url.NormalizeURLDetailed(raw)
+	}
+
+	u, err := url.NormalizeURLWithBase(raw, opts.baseURL)
+	if err != nil {
+		return "", url.HostPort{}, err
+	}
+
+	if !opts.emitETLD1 {
+		return u, url.HostPort{}, nil
+	}
+
+	_, hp, err := url.NormalizeURLDetailed(u)
+	return u, hp, err
 }
 
-type Line struct {
-	Url string
-	Pos int
+func matchAllow(m *match.Matcher, indicator string) (match.Rule, bool) {
+	if m == nil {
+		return match.Rule{}, false
+	}
+	return m.Match(indicator)
 }
 
-func (l Line) Empty() bool {
-	return l.Url == ""
+// writeOrdered reassembles results (which may arrive out of order) into
+// strictly increasing sequence order before writing them out, using a small
+// reordering buffer keyed by sequence number.
+func writeOrdered(cancel context.CancelFunc, results <-chan result, out *os.File, iFile string, strictMode bool) (succeeded, failed int) {
+	pending := make(map[int]result)
+	next := 1
+	stopped := false
+
+	for r := range results {
+		pending[r.pos] = r
+
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if stopped || res.skip {
+				continue
+			}
+
+			if res.err != nil {
+				failed++
+				log.Println(fmt.Sprintf("parse error at \"%s:%d\": %s", iFile, res.pos, res.raw))
+				if strictMode {
+					cancel()
+					stopped = true
+				}
+				continue
+			}
+
+			if _, err := out.WriteString(res.out + "\n"); err != nil {
+				cancel()
+				log.Println(err)
+				stopped = true
+				continue
+			}
+
+			succeeded++
+		}
+	}
+
+	return succeeded, failed
 }
 
 func openFile(fname string, def *os.File) (*os.File, error) {
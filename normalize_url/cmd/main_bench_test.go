@@ -0,0 +1,54 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// benchURLs is a small fixed corpus, replayed for every b.N iteration, wide
+// enough to exercise the IDN, defang and path-normalization branches of
+// normalize.
+var benchURLs = []string{
+	"https://example.com/a",
+	"http://Sub.Example.COM/b?x=1",
+	"ftp://files.example.org/report.pdf",
+	"hxxp://evil[.]com/path",
+	"https://example.co.uk/path/../other",
+	"https://xn--e1afmkfd.xn--p1ai",
+}
+
+// BenchmarkProcess runs the same job set through process at increasing
+// worker counts, so -bench output across sub-benchmarks shows whether the
+// worker pool actually buys a speedup as workers grows.
+func BenchmarkProcess(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				jobs := make(chan Line, len(benchURLs))
+				for j, u := range benchURLs {
+					jobs <- Line{Url: u, Pos: j + 1}
+				}
+				close(jobs)
+
+				results := process(context.Background(), jobs, workers, workerOptions{})
+				for range results {
+				}
+			}
+		})
+	}
+}
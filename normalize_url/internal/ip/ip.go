@@ -12,12 +12,14 @@ var (
 	ErrInvalidIPv4 = errors.New("invalid IPv4 format")
 )
 
-// NormalizeIPv4 normalizes IPv4.
+// NormalizeIPv4 normalizes IPv4, first reversing any defanging applied to s
+// (e.g. "1.1.1[.]1").
 func NormalizeIPv4(s string) (string, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return "", ErrEmptyValue
 	}
+	s = Refang(s)
 
 	octets := strings.Split(s, ".")
 	if len(octets) > 4 {
@@ -42,30 +44,6 @@ func NormalizeIPv4(s string) (string, error) {
 	return resultRow, nil
 }
 
-// NormalizeIPv6 normalizes IPv6.
-func NormalizeIPv6(ip string) (string, error) {
-	hasLBracket := strings.HasPrefix(ip, "[")
-	hasRBracket := strings.HasSuffix(ip, "]")
-
-	ip = strings.Trim(ip, "[]")
-	if ip == "" {
-		return "", ErrEmptyValue
-	}
-
-	pip := net.ParseIP(ip)
-	if pip == nil {
-		return "", errors.New("not IPv6")
-	}
-	ip = pip.String()
-	if hasLBracket {
-		ip = "[" + ip
-	}
-	if hasRBracket {
-		ip += "]"
-	}
-	return ip, nil
-}
-
 func parseNumber(str string, blocksCount int) (uint32, error) {
 	var (
 		num  uint64
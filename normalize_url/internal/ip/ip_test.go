@@ -136,3 +136,126 @@ func TestIPv6Normalization(t *testing.T) {
 		}
 	}
 }
+
+func TestRefangDefangIP(t *testing.T) {
+	type testCase struct {
+		defanged string
+		refanged string
+	}
+	var cases = [...]testCase{
+		{"1.1.1[.]1", "1.1.1.1"},
+		{"1(.)1(.)1(.)1", "1.1.1.1"},
+		{"1[dot]1[dot]1[dot]1", "1.1.1.1"},
+	}
+
+	for _, c := range cases {
+		if got := Refang(c.defanged); got != c.refanged {
+			t.Errorf("Refang(%q) = %q, want %q", c.defanged, got, c.refanged)
+		}
+	}
+
+	if got := Defang("1.1.1.1", DefangBrackets); got != "1[.]1[.]1[.]1" {
+		t.Errorf("Defang(brackets) = %q", got)
+	}
+	if got := Defang("1.1.1.1", DefangParens); got != "1(.)1(.)1(.)1" {
+		t.Errorf("Defang(parens) = %q", got)
+	}
+	if got := Defang("1.1.1.1", DefangDot); got != "1[dot]1[dot]1[dot]1" {
+		t.Errorf("Defang(dot) = %q", got)
+	}
+}
+
+func TestNormalizeDefangedIP(t *testing.T) {
+	if got, err := NormalizeIPv4("1.1.1[.]1"); err != nil || got != "1.1.1.1" {
+		t.Fatalf("NormalizeIPv4(defanged) = (%q, %v), want (\"1.1.1.1\", nil)", got, err)
+	}
+	if got, err := NormalizeIPv6("::ffff:192.168.1[.]1"); err != nil || got != "::ffff:192.168.1.1" {
+		t.Fatalf("NormalizeIPv6(defanged) = (%q, %v), want (\"::ffff:192.168.1.1\", nil)", got, err)
+	}
+}
+
+func TestNormalizeIPv6Zone(t *testing.T) {
+	type testCase struct {
+		input        string
+		wantAddress  string
+		wantZone     string
+		wantRendered string
+	}
+	var cases = [...]testCase{
+		{
+			input:        "fe80::1%eth0",
+			wantAddress:  "fe80::1",
+			wantZone:     "eth0",
+			wantRendered: "fe80::1%eth0",
+		},
+		{
+			input:        "[fe80::1%25eth0]",
+			wantAddress:  "fe80::1",
+			wantZone:     "eth0",
+			wantRendered: "[fe80::1%eth0]",
+		},
+	}
+
+	for _, tt := range cases {
+		v6, err := NormalizeIPv6Detailed(tt.input)
+		if err != nil {
+			t.Fatalf("input: %q: %v", tt.input, err)
+		}
+		if v6.Address != tt.wantAddress || v6.Zone != tt.wantZone {
+			t.Errorf("input: %q, got address=%q zone=%q", tt.input, v6.Address, v6.Zone)
+		}
+
+		got, err := NormalizeIPv6(tt.input)
+		if err != nil {
+			t.Fatalf("input: %q: %v", tt.input, err)
+		}
+		if got != tt.wantRendered {
+			t.Errorf("NormalizeIPv6(%q) = %q, want %q", tt.input, got, tt.wantRendered)
+		}
+	}
+}
+
+func TestNormalizeIPv6InvalidZone(t *testing.T) {
+	if _, err := NormalizeIPv6Detailed("fe80::1%"); err == nil {
+		t.Fatal("expected error for empty zone id")
+	}
+	if _, err := NormalizeIPv6Detailed("fe80::1%eth/0"); err == nil {
+		t.Fatal("expected error for zone id with reserved character")
+	}
+}
+
+func TestNormalizeIPv6Embedded4(t *testing.T) {
+	type testCase struct {
+		input    string
+		expected string
+	}
+	var cases = [...]testCase{
+		{
+			input:    "::ffff:192.168.1.1",
+			expected: "::ffff:192.168.1.1",
+		},
+		{
+			input:    "::ffff:0x01020304",
+			expected: "::ffff:1.2.3.4",
+		},
+		{
+			input:    "64:ff9b::192.0.2.33",
+			expected: "64:ff9b::192.0.2.33",
+		},
+		{
+			input:    "2001:db8::c000:221",
+			expected: "2001:db8::c000:221",
+		},
+	}
+
+	for _, tt := range cases {
+		result, err := NormalizeIPv6(tt.input)
+		if err != nil {
+			t.Errorf("input: %q: %v", tt.input, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("input: %q, expected: %q, actual: %q", tt.input, tt.expected, result)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidZone = errors.New("invalid ipv6 zone id")
+	ErrNotIPv6     = errors.New("not IPv6")
+)
+
+// zoneRe matches a zone_id per RFC 6874/RFC 4007: unreserved characters only.
+var zoneRe = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+
+// hexGroupRe matches a plain IPv6 hex group, to tell it apart from an
+// obfuscated IPv4 tail written as a single number (e.g. "0x01020304").
+var hexGroupRe = regexp.MustCompile(`^[0-9a-fA-F]{1,4}$`)
+
+// nat64Prefix is the well-known NAT64 translation prefix (RFC 6052), one of
+// the two prefixes for which the embedded IPv4 tail is kept dotted.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// IPv6 is a normalized IPv6 address, split out from its optional zone ID and
+// embedded IPv4 tail so callers can apply their own policy around them.
+type IPv6 struct {
+	// Address is the RFC 5952 shortest-form address, without the zone.
+	Address string
+	// Zone is the zone identifier (RFC 6874), without its "%" delimiter, or
+	// empty if the address has none.
+	Zone string
+	// Embedded4 is the normalized dotted-quad IPv4 tail, or empty if the
+	// address has none.
+	Embedded4 string
+	// IsMapped reports whether Address is an IPv4-mapped IPv6 address
+	// (::ffff:0:0/96).
+	IsMapped bool
+}
+
+// String returns the address with its zone, if any, reattached using the
+// literal "%" delimiter (RFC 4007). Use normalizeHost's %25-encoding when
+// embedding the result in a URL host instead.
+func (a IPv6) String() string {
+	if a.Zone == "" {
+		return a.Address
+	}
+	return a.Address + "%" + a.Zone
+}
+
+// NormalizeIPv6 normalizes IPv6, preserving brackets if s has them.
+func NormalizeIPv6(s string) (string, error) {
+	hasLBracket := strings.HasPrefix(s, "[")
+	hasRBracket := strings.HasSuffix(s, "]")
+
+	v6, err := NormalizeIPv6Detailed(s)
+	if err != nil {
+		return "", err
+	}
+
+	out := v6.String()
+	if hasLBracket {
+		out = "[" + out
+	}
+	if hasRBracket {
+		out += "]"
+	}
+	return out, nil
+}
+
+// NormalizeIPv6Detailed reverses any defanging applied to s, then normalizes
+// it as an IPv6 address, splitting out its zone ID (RFC 6874) and
+// canonicalizing any embedded IPv4 tail (RFC 4291 §2.5.5), including
+// obfuscated forms such as "::ffff:0x01020304". The dotted-quad form of the
+// tail is only kept for the well-known prefixes ::ffff:0:0/96 and
+// 64:ff9b::/96; elsewhere it is folded into the hex groups.
+func NormalizeIPv6Detailed(s string) (IPv6, error) {
+	s = strings.Trim(s, "[]")
+	if s == "" {
+		return IPv6{}, ErrEmptyValue
+	}
+	s = Refang(s)
+
+	addr, zone, err := splitZone(s)
+	if err != nil {
+		return IPv6{}, err
+	}
+
+	addr, embedded4 := normalizeEmbedded4(addr)
+
+	a, err := netip.ParseAddr(addr)
+	if err != nil || !a.Is6() {
+		return IPv6{}, ErrNotIPv6
+	}
+
+	return IPv6{
+		Address:   canonicalAddress(a, embedded4),
+		Zone:      zone,
+		Embedded4: embedded4,
+		IsMapped:  a.Is4In6(),
+	}, nil
+}
+
+// splitZone separates the zone identifier from addr. A zone may be
+// delimited either by a literal "%" (plain-text indicator) or by "%25"
+// (RFC 6874, when the address is lifted out of a URL host).
+func splitZone(addr string) (string, string, error) {
+	addr = strings.Replace(addr, "%25", "%", 1)
+
+	i := strings.IndexByte(addr, '%')
+	if i < 0 {
+		return addr, "", nil
+	}
+
+	zone := addr[i+1:]
+	if unescaped, err := url.QueryUnescape(zone); err == nil {
+		zone = unescaped
+	}
+	if zone == "" || !zoneRe.MatchString(zone) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidZone, zone)
+	}
+
+	return addr[:i], zone, nil
+}
+
+// normalizeEmbedded4 detects and normalizes an IPv4 tail after the last ':',
+// accepting the obfuscated forms NormalizeIPv4 accepts. It returns addr with
+// the tail rewritten to its canonical dotted-quad form, and that form (or
+// "" if addr has no embedded IPv4 tail).
+func normalizeEmbedded4(addr string) (string, string) {
+	i := strings.LastIndexByte(addr, ':')
+	if i < 0 || i == len(addr)-1 {
+		return addr, ""
+	}
+
+	tail := addr[i+1:]
+	if !looksLikeIPv4(tail) {
+		return addr, ""
+	}
+
+	v4, err := NormalizeIPv4(tail)
+	if err != nil {
+		return addr, ""
+	}
+
+	return addr[:i+1] + v4, v4
+}
+
+func looksLikeIPv4(s string) bool {
+	if strings.Contains(s, ".") {
+		return true
+	}
+	if hexGroupRe.MatchString(s) {
+		return false
+	}
+	_, err := NormalizeIPv4(s)
+	return err == nil
+}
+
+func canonicalAddress(a netip.Addr, embedded4 string) string {
+	if embedded4 == "" {
+		return a.String()
+	}
+	if a.Is4In6() {
+		return a.String() // stdlib already renders this prefix dotted
+	}
+	if nat64Prefix.Contains(a) {
+		return withDottedTail(a, embedded4)
+	}
+	return a.String() // folded into hex groups, dotted tail not kept
+}
+
+// withDottedTail renders a with its final 32 bits replaced by the dotted
+// IPv4 tail v4, for prefixes net/netip doesn't dot automatically.
+func withDottedTail(a netip.Addr, v4 string) string {
+	b := a.As16()
+	var prefix [16]byte
+	copy(prefix[:12], b[:12])
+
+	s := netip.AddrFrom16(prefix).String()
+	if strings.HasSuffix(s, "::") {
+		return s + v4
+	}
+	return s + ":" + v4
+}
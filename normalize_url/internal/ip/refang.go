@@ -0,0 +1,55 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ip
+
+import "strings"
+
+// DefangStyle selects the notation Defang uses to neutralize an IP indicator.
+type DefangStyle int
+
+const (
+	// DefangBrackets renders dots as "[.]".
+	DefangBrackets DefangStyle = iota
+	// DefangParens renders dots as "(.)".
+	DefangParens
+	// DefangDot renders dots as "[dot]".
+	DefangDot
+)
+
+var refangDots = strings.NewReplacer(
+	"[.]", ".",
+	"(.)", ".",
+	"[dot]", ".",
+	"[DOT]", ".",
+)
+
+// Refang reverses common "defanging" transformations applied to standalone IP
+// indicators, e.g. "1.1.1[.]1" becomes "1.1.1.1".
+func Refang(s string) string {
+	s = strings.ReplaceAll(s, "\u200b", "") // zero-width space
+	s = strings.ReplaceAll(s, "\ufeff", "") // zero-width no-break space / BOM
+	return refangDots.Replace(s)
+}
+
+// Defang renders s in the given defanged notation so it won't auto-link when
+// shared in reports.
+func Defang(s string, style DefangStyle) string {
+	switch style {
+	case DefangParens:
+		return strings.ReplaceAll(s, ".", "(.)")
+	case DefangDot:
+		return strings.ReplaceAll(s, ".", "[dot]")
+	default:
+		return strings.ReplaceAll(s, ".", "[.]")
+	}
+}
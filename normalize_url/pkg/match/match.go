@@ -0,0 +1,347 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package match matches normalized URL and IP indicators against
+// user-supplied blocklists/allowlists. A single Matcher loads one list (a
+// blocklist or an allowlist) made of one rule per line; callers combine a
+// block Matcher and an allow Matcher to get block/allow semantics.
+package match
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"un/pkg/url/suffix"
+)
+
+// RuleType identifies the kind of pattern a Rule was built from.
+type RuleType int
+
+const (
+	// RuleHost matches a single host exactly, e.g. "=login.example.com".
+	RuleHost RuleType = iota
+	// RuleDomain matches a registrable domain and any of its subdomains.
+	RuleDomain
+	// RuleURLPrefix matches a literal prefix of the normalized URL.
+	RuleURLPrefix
+	// RuleCIDR matches an IPv4 or IPv6 address within a CIDR range.
+	RuleCIDR
+)
+
+// Rule is the list entry that matched an indicator.
+type Rule struct {
+	Type    RuleType
+	Pattern string
+}
+
+type domainNode struct {
+	children map[string]*domainNode
+	rule     *Rule
+}
+
+type cidrRuleV4 struct {
+	start, end uint32
+	rule       Rule
+}
+
+type cidrRuleV6 struct {
+	start, end [16]byte
+	rule       Rule
+}
+
+// Matcher holds the rules of a single list (blocklist or allowlist), indexed
+// for sub-linear lookup: a map for exact-host rules, a trie for domain rules
+// and sorted CIDR ranges for IP rules.
+type Matcher struct {
+	hosts    map[string]Rule
+	domains  *domainNode
+	prefixes []Rule
+	cidrsV4  []cidrRuleV4
+	cidrsV6  []cidrRuleV6
+}
+
+// New returns an empty Matcher ready to have rules loaded into it.
+func New() *Matcher {
+	return &Matcher{
+		hosts:   map[string]Rule{},
+		domains: &domainNode{children: map[string]*domainNode{}},
+	}
+}
+
+// LoadFile reads rules from path, one per line. See LoadReader for the
+// supported line formats.
+func (m *Matcher) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.LoadReader(f)
+}
+
+// LoadReader reads rules from r, one per line. Blank lines and lines
+// starting with "#" are ignored. A line is interpreted, in order, as:
+//
+//   - a CIDR range ("1.2.3.0/24", "2001:db8::/32")
+//   - a bare IP address ("1.2.3.4"), treated as a /32 or /128
+//   - an exact host, when prefixed with "=" ("=login.example.com")
+//   - a URL prefix, when it contains a scheme or a path ("example.com/admin")
+//   - otherwise, a registrable domain, matching it and all its subdomains
+//     ("example.com")
+func (m *Matcher) LoadReader(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanLines)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := m.addRule(line); err != nil {
+			return fmt.Errorf("match: invalid rule %q: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	m.finalize()
+	return nil
+}
+
+func (m *Matcher) addRule(line string) error {
+	if _, ipnet, err := net.ParseCIDR(line); err == nil {
+		return m.addCIDR(ipnet, Rule{Type: RuleCIDR, Pattern: line})
+	}
+
+	if ip := net.ParseIP(line); ip != nil {
+		ones := 32
+		if ip.To4() == nil {
+			ones = 128
+		}
+		_, ipnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", line, ones))
+		if err != nil {
+			return err
+		}
+		return m.addCIDR(ipnet, Rule{Type: RuleCIDR, Pattern: line})
+	}
+
+	if strings.HasPrefix(line, "=") {
+		host := strings.ToLower(strings.TrimPrefix(line, "="))
+		m.hosts[host] = Rule{Type: RuleHost, Pattern: host}
+		return nil
+	}
+
+	if strings.Contains(line, "://") || strings.Contains(line, "/") {
+		m.prefixes = append(m.prefixes, Rule{Type: RuleURLPrefix, Pattern: strings.ToLower(stripScheme(line))})
+		return nil
+	}
+
+	host := strings.ToLower(line)
+	if _, err := suffix.RegistrableDomain(host); err != nil {
+		return fmt.Errorf("%q is a bare public suffix, not a registrable domain: %w", host, err)
+	}
+
+	m.insertDomain(host)
+	return nil
+}
+
+func (m *Matcher) insertDomain(host string) {
+	labels := strings.Split(host, ".")
+
+	n := m.domains
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+		child, ok := n.children[label]
+		if !ok {
+			child = &domainNode{children: map[string]*domainNode{}}
+			n.children[label] = child
+		}
+		n = child
+	}
+
+	rule := Rule{Type: RuleDomain, Pattern: host}
+	n.rule = &rule
+}
+
+func (m *Matcher) addCIDR(ipnet *net.IPNet, rule Rule) error {
+	ones, bits := ipnet.Mask.Size()
+
+	if v4 := ipnet.IP.To4(); v4 != nil && bits == 32 {
+		start := binary.BigEndian.Uint32(v4)
+		var mask uint32 = 0xFFFFFFFF
+		if ones < 32 {
+			mask <<= 32 - ones
+		}
+		start &= mask
+		end := start | ^mask
+		m.cidrsV4 = append(m.cidrsV4, cidrRuleV4{start: start, end: end, rule: rule})
+		return nil
+	}
+
+	v6 := ipnet.IP.To16()
+	if v6 == nil {
+		return errors.New("unsupported address family")
+	}
+
+	var start, end [16]byte
+	copy(start[:], v6)
+	end = start
+	for i := ones; i < 128; i++ {
+		end[i/8] |= 1 << (7 - i%8)
+	}
+	m.cidrsV6 = append(m.cidrsV6, cidrRuleV6{start: start, end: end, rule: rule})
+
+	return nil
+}
+
+func (m *Matcher) finalize() {
+	// Ranges sharing a start are ordered widest first, so the narrower one
+	// sits later and is found first by the backwards scans in matchCIDRv4/v6.
+	sort.Slice(m.cidrsV4, func(i, j int) bool {
+		if m.cidrsV4[i].start != m.cidrsV4[j].start {
+			return m.cidrsV4[i].start < m.cidrsV4[j].start
+		}
+		return m.cidrsV4[i].end > m.cidrsV4[j].end
+	})
+	sort.Slice(m.cidrsV6, func(i, j int) bool {
+		if c := bytes.Compare(m.cidrsV6[i].start[:], m.cidrsV6[j].start[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(m.cidrsV6[i].end[:], m.cidrsV6[j].end[:]) > 0
+	})
+}
+
+// Match reports whether indicator - a normalized URL (scheme-less,
+// "host/path"), bare host, or bare IP - matches a rule in the list, and if
+// so, which one. Host/domain rules and CIDR rules are checked first
+// (sub-linear), then URL-prefix rules.
+func (m *Matcher) Match(indicator string) (Rule, bool) {
+	host := indicator
+	if i := strings.IndexAny(indicator, "/?"); i >= 0 {
+		host = indicator[:i]
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+
+	if ip := net.ParseIP(host); ip != nil {
+		if r, ok := m.matchIP(ip); ok {
+			return r, true
+		}
+	} else {
+		if r, ok := m.hosts[host]; ok {
+			return r, true
+		}
+		if r, ok := m.matchDomain(host); ok {
+			return r, true
+		}
+	}
+
+	return m.matchPrefix(strings.ToLower(indicator))
+}
+
+func (m *Matcher) matchDomain(host string) (Rule, bool) {
+	labels := strings.Split(host, ".")
+
+	n := m.domains
+	var best *Rule
+	for i := len(labels) - 1; i >= 0; i-- {
+		child, ok := n.children[labels[i]]
+		if !ok {
+			break
+		}
+		n = child
+		if n.rule != nil {
+			best = n.rule
+		}
+	}
+
+	if best == nil {
+		return Rule{}, false
+	}
+	return *best, true
+}
+
+func (m *Matcher) matchIP(ip net.IP) (Rule, bool) {
+	if v4 := ip.To4(); v4 != nil {
+		return m.matchCIDRv4(binary.BigEndian.Uint32(v4))
+	}
+
+	var v6 [16]byte
+	copy(v6[:], ip.To16())
+	return m.matchCIDRv6(v6)
+}
+
+// matchCIDRv4 finds a range containing ip among the ranges starting at or
+// before it. sort.Search only narrows that candidate set; ranges can nest or
+// overlap (e.g. 10.0.0.0/8 followed by the narrower 10.1.0.0/16), so every
+// candidate is checked, innermost (largest start) first, instead of just the
+// one immediately preceding ip.
+func (m *Matcher) matchCIDRv4(ip uint32) (Rule, bool) {
+	i := sort.Search(len(m.cidrsV4), func(i int) bool { return m.cidrsV4[i].start > ip })
+	for j := i - 1; j >= 0; j-- {
+		if c := m.cidrsV4[j]; ip <= c.end {
+			return c.rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// matchCIDRv6 is matchCIDRv4's counterpart for 16-byte addresses; see its
+// comment for why every candidate with start <= ip must be checked.
+func (m *Matcher) matchCIDRv6(ip [16]byte) (Rule, bool) {
+	i := sort.Search(len(m.cidrsV6), func(i int) bool { return bytes.Compare(m.cidrsV6[i].start[:], ip[:]) > 0 })
+	for j := i - 1; j >= 0; j-- {
+		if c := m.cidrsV6[j]; bytes.Compare(ip[:], c.end[:]) <= 0 {
+			return c.rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// matchPrefix reports whether indicator starts with a rule's pattern on a
+// path segment boundary, so a rule for "evil.com/login" doesn't also match
+// an unrelated "evil.com/loginhorse-attack".
+func (m *Matcher) matchPrefix(indicator string) (Rule, bool) {
+	var best Rule
+	found := false
+	for _, r := range m.prefixes {
+		if !strings.HasPrefix(indicator, r.Pattern) {
+			continue
+		}
+		if rest := indicator[len(r.Pattern):]; rest != "" && rest[0] != '/' && rest[0] != '?' {
+			continue
+		}
+		if !found || len(r.Pattern) > len(best.Pattern) {
+			best = r
+			found = true
+		}
+	}
+	return best, found
+}
+
+func stripScheme(s string) string {
+	if i := strings.Index(s, "://"); i >= 0 {
+		return s[i+len("://"):]
+	}
+	return s
+}
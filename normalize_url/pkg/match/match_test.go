@@ -0,0 +1,137 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package match
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestMatcher(t *testing.T, rules string) *Matcher {
+	t.Helper()
+	m := New()
+	if err := m.LoadReader(strings.NewReader(rules)); err != nil {
+		t.Fatalf("LoadReader: %v", err)
+	}
+	return m
+}
+
+func TestMatchDomain(t *testing.T) {
+	m := newTestMatcher(t, "example.com\n")
+
+	if _, ok := m.Match("example.com/path"); !ok {
+		t.Fatal("expected example.com to match")
+	}
+	if _, ok := m.Match("a.b.example.com/path"); !ok {
+		t.Fatal("expected a subdomain of example.com to match")
+	}
+	if _, ok := m.Match("notexample.com"); ok {
+		t.Fatal("did not expect notexample.com to match")
+	}
+}
+
+func TestMatchExactHost(t *testing.T) {
+	m := newTestMatcher(t, "=login.example.com\n")
+
+	if _, ok := m.Match("login.example.com"); !ok {
+		t.Fatal("expected exact host to match")
+	}
+	if _, ok := m.Match("a.login.example.com"); ok {
+		t.Fatal("did not expect a subdomain to match an exact host rule")
+	}
+}
+
+func TestMatchURLPrefix(t *testing.T) {
+	m := newTestMatcher(t, "evil.com/login\n")
+
+	if _, ok := m.Match("evil.com/login/submit"); !ok {
+		t.Fatal("expected prefix match")
+	}
+	if _, ok := m.Match("evil.com/login?a=1"); !ok {
+		t.Fatal("expected prefix match with a query boundary")
+	}
+	if _, ok := m.Match("evil.com/login"); !ok {
+		t.Fatal("expected an exact match")
+	}
+	if _, ok := m.Match("evil.com/other"); ok {
+		t.Fatal("did not expect unrelated path to match")
+	}
+	if _, ok := m.Match("evil.com/loginhorse-attack"); ok {
+		t.Fatal("did not expect a sibling path sharing the prefix to match")
+	}
+}
+
+func TestMatchCIDRv4(t *testing.T) {
+	m := newTestMatcher(t, "1.2.0.0/16\n")
+
+	if _, ok := m.Match("1.2.3.4"); !ok {
+		t.Fatal("expected 1.2.3.4 to match 1.2.0.0/16")
+	}
+	if _, ok := m.Match("1.3.3.4"); ok {
+		t.Fatal("did not expect 1.3.3.4 to match")
+	}
+}
+
+func TestMatchCIDRv4Nested(t *testing.T) {
+	m := newTestMatcher(t, "10.0.0.0/8\n10.1.0.0/16\n")
+
+	if _, ok := m.Match("10.2.3.4"); !ok {
+		t.Fatal("expected 10.2.3.4 to match the broader 10.0.0.0/8, outside the narrower 10.1.0.0/16")
+	}
+	if _, ok := m.Match("10.1.2.3"); !ok {
+		t.Fatal("expected 10.1.2.3 to match the narrower 10.1.0.0/16")
+	}
+	if _, ok := m.Match("11.0.0.1"); ok {
+		t.Fatal("did not expect 11.0.0.1 to match")
+	}
+}
+
+func TestMatchCIDRv6Nested(t *testing.T) {
+	m := newTestMatcher(t, "2001:db8::/32\n2001:db8:1::/48\n")
+
+	if _, ok := m.Match("2001:db8:2::1"); !ok {
+		t.Fatal("expected 2001:db8:2::1 to match the broader 2001:db8::/32, outside the narrower 2001:db8:1::/48")
+	}
+	if _, ok := m.Match("2001:db8:1::1"); !ok {
+		t.Fatal("expected 2001:db8:1::1 to match the narrower 2001:db8:1::/48")
+	}
+	if _, ok := m.Match("2001:db9::1"); ok {
+		t.Fatal("did not expect 2001:db9::1 to match")
+	}
+}
+
+func TestMatchCIDRv6MappedIPv4(t *testing.T) {
+	m := newTestMatcher(t, "1.2.0.0/16\n")
+
+	if _, ok := m.Match("::ffff:1.2.3.4"); !ok {
+		t.Fatal("expected IPv4-mapped IPv6 address to match the IPv4 CIDR rule")
+	}
+}
+
+func TestLoadReaderRejectsBarePublicSuffix(t *testing.T) {
+	m := New()
+	if err := m.LoadReader(strings.NewReader("co.uk\n")); err == nil {
+		t.Fatal("expected an error loading a bare public suffix as a domain rule")
+	}
+}
+
+func TestMatchBareIP(t *testing.T) {
+	m := newTestMatcher(t, "1.2.3.4\n")
+
+	if _, ok := m.Match("1.2.3.4"); !ok {
+		t.Fatal("expected exact IP rule to match")
+	}
+	if _, ok := m.Match("1.2.3.5"); ok {
+		t.Fatal("did not expect a different IP to match")
+	}
+}
@@ -0,0 +1,60 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package url
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+// BenchmarkNormalizeURL measures NormalizeURL throughput over testdata/urls.csv,
+// cycling through the corpus as b.N grows so the effective input set scales
+// with the benchmark's run length.
+func BenchmarkNormalizeURL(b *testing.B) {
+	urls := loadBenchURLs(b)
+	if len(urls) == 0 {
+		b.Skipf("no urls available in %s", filename)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = NormalizeURL(urls[i%len(urls)])
+	}
+}
+
+func loadBenchURLs(b *testing.B) []string {
+	b.Helper()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var urls []string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Split(bufio.ScanLines)
+	for scanner.Scan() {
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) == 2 {
+			urls = append(urls, cols[0])
+		}
+	}
+
+	return urls
+}
@@ -0,0 +1,81 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package url
+
+import "strings"
+
+// DefangStyle selects the notation Defang uses to neutralize an indicator.
+type DefangStyle int
+
+const (
+	// DefangBrackets renders dots as "[.]".
+	DefangBrackets DefangStyle = iota
+	// DefangParens renders dots as "(.)".
+	DefangParens
+	// DefangDot renders dots as "[dot]".
+	DefangDot
+)
+
+var refangDots = strings.NewReplacer(
+	"[.]", ".",
+	"(.)", ".",
+	"[dot]", ".",
+	"[DOT]", ".",
+)
+
+var refangSchemes = []struct{ from, to string }{
+	{"hxxps", "https"},
+	{"hxxp", "http"},
+	{"fxp", "ftp"},
+}
+
+// Refang reverses common "defanging" transformations applied to indicators in
+// threat-intel feeds and reports so they don't auto-link, e.g.
+// "hxxp://evil[.]com/path" becomes "http://evil.com/path".
+func Refang(s string) string {
+	s = strings.ReplaceAll(s, "\u200b", "") // zero-width space
+	s = strings.ReplaceAll(s, "\ufeff", "") // zero-width no-break space / BOM
+	s = refangDots.Replace(s)
+
+	lower := strings.ToLower(s)
+	for _, r := range refangSchemes {
+		if strings.HasPrefix(lower, r.from+"://") {
+			s = r.to + s[len(r.from):]
+			break
+		}
+	}
+
+	return s
+}
+
+// Defang renders s in the given defanged notation so it won't auto-link when
+// shared in reports. Whatever the style, an http, https or ftp scheme is
+// rewritten as "hxxp", "hxxps" or "fxp".
+func Defang(s string, style DefangStyle) string {
+	lower := strings.ToLower(s)
+	for _, r := range refangSchemes {
+		if strings.HasPrefix(lower, r.to+"://") {
+			s = r.from + s[len(r.to):]
+			break
+		}
+	}
+
+	switch style {
+	case DefangParens:
+		return strings.ReplaceAll(s, ".", "(.)")
+	case DefangDot:
+		return strings.ReplaceAll(s, ".", "[dot]")
+	default:
+		return strings.ReplaceAll(s, ".", "[.]")
+	}
+}
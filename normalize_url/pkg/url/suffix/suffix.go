@@ -0,0 +1,161 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package suffix exposes the Mozilla Public Suffix List, embedded at build
+// time, for extracting the public suffix and registrable domain (eTLD+1) of
+// a host.
+package suffix
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"log"
+	"strings"
+)
+
+//go:generate curl -sSL -o public_suffix_list.dat https://publicsuffix.org/list/public_suffix_list.dat
+
+//go:embed public_suffix_list.dat
+var fs embed.FS
+
+var ErrNoRegistrableDomain = errors.New("host has no registrable domain")
+
+type node struct {
+	children  map[string]*node
+	terminal  bool
+	exception bool
+}
+
+var root *node
+
+func init() {
+	root = &node{children: map[string]*node{}}
+
+	f, err := fs.Open("public_suffix_list.dat")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Split(bufio.ScanLines)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		insert(line)
+	}
+}
+
+func insert(rule string) {
+	exception := strings.HasPrefix(rule, "!")
+	rule = strings.TrimPrefix(rule, "!")
+
+	labels := strings.Split(rule, ".")
+	n := root
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+		child, ok := n.children[label]
+		if !ok {
+			child = &node{children: map[string]*node{}}
+			n.children[label] = child
+		}
+		n = child
+	}
+
+	if exception {
+		n.exception = true
+	} else {
+		n.terminal = true
+	}
+}
+
+// PublicSuffix returns the public suffix of host and whether an explicit
+// rule from the list matched. When no rule matches, the last label is
+// returned as the default "*" rule prescribes, with matched set to false.
+func PublicSuffix(host string) (string, bool) {
+	labels := splitLabels(host)
+	if len(labels) == 0 {
+		return "", false
+	}
+
+	n := root
+	depth := 0
+	bestDepth := 1
+	bestException := false
+	matched := false
+
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+
+		next, ok := n.children[label]
+		if !ok {
+			next, ok = n.children["*"]
+		}
+		if !ok {
+			break
+		}
+
+		depth++
+		n = next
+
+		if n.terminal || n.exception {
+			matched = true
+			bestDepth = depth
+			bestException = n.exception
+		}
+	}
+
+	if bestException {
+		bestDepth--
+	}
+	if bestDepth > len(labels) {
+		bestDepth = len(labels)
+	}
+
+	return strings.Join(labels[len(labels)-bestDepth:], "."), matched
+}
+
+// RegistrableDomain returns the eTLD+1 of host: its public suffix plus the
+// one label immediately to the left of it. It returns ErrNoRegistrableDomain
+// when host is itself a public suffix or shorter.
+func RegistrableDomain(host string) (string, error) {
+	labels := splitLabels(host)
+	psuffix, _ := PublicSuffix(host)
+	suffixLabels := strings.Split(psuffix, ".")
+
+	if psuffix == "" || len(labels) <= len(suffixLabels) {
+		return "", ErrNoRegistrableDomain
+	}
+
+	start := len(labels) - len(suffixLabels) - 1
+	return strings.Join(labels[start:], "."), nil
+}
+
+// IsRegistrableDomain reports whether host is exactly its own eTLD+1, i.e.
+// a public suffix with a single label prepended and nothing else.
+func IsRegistrableDomain(host string) bool {
+	rd, err := RegistrableDomain(host)
+	return err == nil && rd == host
+}
+
+func splitLabels(host string) []string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return nil
+	}
+	return strings.Split(host, ".")
+}
@@ -0,0 +1,75 @@
+// © 2024 AO Kaspersky Lab. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package suffix
+
+import "testing"
+
+func TestPublicSuffix(t *testing.T) {
+	type testCase struct {
+		host     string
+		expected string
+		matched  bool
+	}
+	var cases = [...]testCase{
+		{"www.example.com", "com", true},
+		{"example.co.uk", "co.uk", true},
+		{"city.kawasaki.jp", "kawasaki.jp", true},
+		{"foo.tokyo.jp", "tokyo.jp", true},
+		{"foo.github.io", "github.io", true},
+		{"unknownsld.unknowntld", "unknowntld", false},
+	}
+
+	for _, c := range cases {
+		got, matched := PublicSuffix(c.host)
+		if got != c.expected || matched != c.matched {
+			t.Fatalf("PublicSuffix(%q) = (%q, %v), want (%q, %v)", c.host, got, matched, c.expected, c.matched)
+		}
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	type testCase struct {
+		host     string
+		expected string
+		wantErr  bool
+	}
+	var cases = [...]testCase{
+		{"www.example.com", "example.com", false},
+		{"a.b.example.co.uk", "example.co.uk", false},
+		{"city.kawasaki.jp", "city.kawasaki.jp", false},
+		{"co.uk", "", true},
+		{"jp", "", true},
+	}
+
+	for _, c := range cases {
+		got, err := RegistrableDomain(c.host)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("RegistrableDomain(%q) error = %v, wantErr %v", c.host, err, c.wantErr)
+		}
+		if err == nil && got != c.expected {
+			t.Fatalf("RegistrableDomain(%q) = %q, want %q", c.host, got, c.expected)
+		}
+	}
+}
+
+func TestIsRegistrableDomain(t *testing.T) {
+	if !IsRegistrableDomain("example.com") {
+		t.Fatal("expected example.com to be a registrable domain")
+	}
+	if IsRegistrableDomain("www.example.com") {
+		t.Fatal("expected www.example.com not to be a registrable domain")
+	}
+	if IsRegistrableDomain("co.uk") {
+		t.Fatal("expected co.uk (a bare public suffix) not to be a registrable domain")
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 	"un/internal/ip"
+	"un/pkg/url/suffix"
+
+	"golang.org/x/net/idna"
 )
 
 const (
@@ -17,35 +20,90 @@ const (
 var (
 	ErrInvalidHost = errors.New("invalid url host")
 	ErrInvalidPath = errors.New("invalid url path")
+	ErrBaseNotAbs  = errors.New("base url must be absolute")
+)
+
+// idnaProfile converts unicode hostnames to their ASCII Punycode form in
+// non-transitional mode and rejects disallowed code points, labels breaking
+// the bidi rule, empty labels and labels/names exceeding RFC 1035 length
+// limits. Labels mixing scripts (e.g. Latin and Cyrillic) are not rejected.
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.ValidateLabels(true),
+	idna.VerifyDNSLength(true),
+	idna.RemoveLeadingDots(true),
 )
 
 type HostPort struct {
-	Host string
-	Port string
-	IsIP bool
+	Host              string
+	Port              string
+	IsIP              bool
+	PublicSuffix      string
+	RegistrableDomain string
 }
 
 func NormalizeURL(raw string) (string, error) {
+	res, _, err := NormalizeURLDetailed(raw)
+	return res, err
+}
+
+// NormalizeURLDetailed normalizes raw the same way NormalizeURL does, and
+// additionally returns the parsed HostPort, which carries the public suffix
+// and registrable domain (eTLD+1) of the host.
+func NormalizeURLDetailed(raw string) (string, HostPort, error) {
 	raw = prepare(raw)
 
 	u, err := parseUrl(raw)
+	if err != nil {
+		return "", HostPort{}, err
+	}
+
+	return normalizeParsed(u, raw)
+}
+
+// NormalizeURLWithBase resolves raw against base per RFC 3986 §5 and
+// normalizes the result the same way NormalizeURL does. base must itself be
+// an absolute URL. Unlike NormalizeURL, raw is not run through withScheme's
+// scheme-guessing fallback, so protocol-relative ("//cdn.example.com/x") and
+// path-relative ("/login", "../a.php") inputs resolve against base instead
+// of being misread as having their own scheme.
+func NormalizeURLWithBase(raw, base string) (string, error) {
+	baseURL, err := parseUrl(strings.TrimSpace(base))
 	if err != nil {
 		return "", err
 	}
+	if !baseURL.IsAbs() {
+		return "", ErrBaseNotAbs
+	}
 
-	hp, err := normalizeHost(u.Host)
+	raw = Refang(strings.TrimLeft(raw, " "))
+
+	ref, err := parseUrl(raw)
 	if err != nil {
 		return "", err
 	}
 
+	resolved := baseURL.ResolveReference(ref)
+
+	res, _, err := normalizeParsed(resolved, raw)
+	return res, err
+}
+
+func normalizeParsed(u *url.URL, raw string) (string, HostPort, error) {
+	hp, err := normalizeHost(u.Host)
+	if err != nil {
+		return "", HostPort{}, err
+	}
+
 	path, err := normalizePath(u.EscapedPath())
 	if err != nil {
-		return "", err
+		return "", HostPort{}, err
 	}
 
 	query, err := normalizeQuery(u.RawQuery)
 	if err != nil {
-		return "", err
+		return "", HostPort{}, err
 	}
 
 	var buf strings.Builder
@@ -86,13 +144,14 @@ func NormalizeURL(raw string) (string, error) {
 
 	res = strings.TrimSuffix(res, "/.")
 
-	return res, nil
+	return res, hp, nil
 }
 
 // prepare prepares string before URL parsing.
 // Removes leading spaces and returns URL with scheme.
 func prepare(raw string) string {
 	raw = strings.TrimLeft(raw, " ")
+	raw = Refang(raw)
 	return withScheme(raw)
 }
 
@@ -119,14 +178,7 @@ func normalizeHost(raw string) (HostPort, error) {
 	parts := strings.Split(strings.ToLower(raw), ":")
 
 	if len(parts) > 2 {
-		nip, err := ip.NormalizeIPv6(raw)
-		if err != nil {
-			return HostPort{}, errors.Join(ErrInvalidHost, err)
-		}
-		return HostPort{
-			Host: nip,
-			IsIP: true,
-		}, nil
+		return normalizeIPv6Host(raw)
 	}
 
 	host := parts[0]
@@ -142,16 +194,70 @@ func normalizeHost(raw string) (HostPort, error) {
 	host = collapse(host, '.', true)
 	host = strings.TrimPrefix(host, ".")
 	host = strings.TrimPrefix(host, "www.")
-	hp.Host = host
 
 	if nip, err := ip.NormalizeIPv4(host); err == nil {
 		hp.Host = nip
 		hp.IsIP = true
+		return hp, nil
+	}
+
+	host, err := toASCIIHost(host)
+	if err != nil {
+		return HostPort{}, errors.Join(ErrInvalidHost, err)
+	}
+	hp.Host = host
+
+	if ps, ok := suffix.PublicSuffix(host); ok {
+		hp.PublicSuffix = ps
+	}
+	if rd, err := suffix.RegistrableDomain(host); err == nil {
+		hp.RegistrableDomain = rd
 	}
 
 	return hp, nil
 }
 
+// normalizeIPv6Host normalizes an IPv6 host, re-encoding its zone ID (if any)
+// with the %25 escape RFC 6874 requires inside a URL host, and preserving
+// raw's bracket round-trip.
+func normalizeIPv6Host(raw string) (HostPort, error) {
+	v6, err := ip.NormalizeIPv6Detailed(raw)
+	if err != nil {
+		return HostPort{}, errors.Join(ErrInvalidHost, err)
+	}
+
+	host := v6.Address
+	if v6.Zone != "" {
+		host = fmt.Sprintf("%s%%25%s", host, v6.Zone)
+	}
+	if strings.HasPrefix(raw, "[") {
+		host = "[" + host
+	}
+	if strings.HasSuffix(raw, "]") {
+		host += "]"
+	}
+
+	return HostPort{Host: host, IsIP: true}, nil
+}
+
+// toASCIIHost decodes percent-encoded UTF-8 in host and converts the result
+// to its ASCII Punycode (xn--) form per RFC 3490/UTS#46.
+func toASCIIHost(host string) (string, error) {
+	// PathUnescape, not QueryUnescape: the latter also turns a literal "+"
+	// into a space, which is form-encoding semantics that don't apply to a
+	// URL host.
+	if decoded, err := url.PathUnescape(host); err == nil {
+		host = decoded
+	}
+
+	ascii, err := idnaProfile.ToASCII(host)
+	if err != nil {
+		return "", err
+	}
+
+	return ascii, nil
+}
+
 func normalizePath(raw string) (string, error) {
 	if raw == "" {
 		return "", nil
@@ -35,6 +35,151 @@ func TestNormalizeURL(t *testing.T) {
 	}
 }
 
+func TestNormalizeHostIDN(t *testing.T) {
+	type testCase struct {
+		input    string
+		expected string
+	}
+	var cases = [...]testCase{
+		{
+			input:    "https://xn--e1afmkfd.xn--p1ai",
+			expected: "xn--e1afmkfd.xn--p1ai",
+		},
+		{
+			input:    "https://пример.рф",
+			expected: "xn--e1afmkfd.xn--p1ai",
+		},
+		{
+			input:    "https://%D0%BF%D1%80%D0%B8%D0%BC%D0%B5%D1%80.%D1%80%D1%84",
+			expected: "xn--e1afmkfd.xn--p1ai",
+		},
+		{
+			input:    "https://example.com",
+			expected: "example.com",
+		},
+	}
+
+	for _, c := range cases {
+		res, err := NormalizeURL(c.input)
+		if err != nil {
+			t.Fatalf("%v for input: %s", err, c.input)
+		}
+		if res != c.expected {
+			t.Fatalf("\nexpected:\t%s\n\tactual: %s\n  for input: %s", c.expected, res, c.input)
+		}
+	}
+}
+
+func TestNormalizeHostIDNInvalid(t *testing.T) {
+	invalid := []string{
+		"https://foo_bar.com",
+		"https://" + strings.Repeat("a", 64) + ".com",
+		// A literal "+" in the host is rejected by idnaProfile's STD3 rules
+		// regardless of how it got there; this must stay an error.
+		"https://a+b.com/x",
+	}
+
+	for _, in := range invalid {
+		if _, err := NormalizeURL(in); err == nil {
+			t.Fatalf("expected error for input: %s", in)
+		}
+	}
+}
+
+func TestNormalizeURLDetailed(t *testing.T) {
+	res, hp, err := NormalizeURLDetailed("https://www.example.co.uk/a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != "example.co.uk/a" {
+		t.Fatalf("unexpected normalized url: %s", res)
+	}
+	if hp.PublicSuffix != "co.uk" {
+		t.Fatalf("unexpected public suffix: %s", hp.PublicSuffix)
+	}
+	if hp.RegistrableDomain != "example.co.uk" {
+		t.Fatalf("unexpected registrable domain: %s", hp.RegistrableDomain)
+	}
+}
+
+func TestRefangDefang(t *testing.T) {
+	type testCase struct {
+		defanged string
+		refanged string
+	}
+	var cases = [...]testCase{
+		{"hxxp://evil[.]com/path", "http://evil.com/path"},
+		{"hxxps://evil(.)com", "https://evil.com"},
+		{"bad(.)example(.)com", "bad.example.com"},
+		{"example[dot]com", "example.com"},
+	}
+
+	for _, c := range cases {
+		if got := Refang(c.defanged); got != c.refanged {
+			t.Fatalf("Refang(%q) = %q, want %q", c.defanged, got, c.refanged)
+		}
+		if got := Defang(c.refanged, DefangBrackets); Refang(got) != c.refanged {
+			t.Fatalf("Defang(%q) round-trip failed, got %q", c.refanged, got)
+		}
+	}
+}
+
+func TestNormalizeURLDefanged(t *testing.T) {
+	res, err := NormalizeURL("hxxp://evil[.]com/path")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != "evil.com/path" {
+		t.Fatalf("unexpected normalized url: %s", res)
+	}
+}
+
+func TestNormalizeURLWithBase(t *testing.T) {
+	type testCase struct {
+		raw      string
+		base     string
+		expected string
+	}
+	var cases = [...]testCase{
+		{
+			raw:      "/login",
+			base:     "https://example.com/articles/2024/",
+			expected: "example.com/login",
+		},
+		{
+			raw:      "../a.php",
+			base:     "https://example.com/articles/2024/",
+			expected: "example.com/articles/a.php",
+		},
+		{
+			raw:      "//cdn.example.com/x",
+			base:     "https://example.com/articles/2024/",
+			expected: "cdn.example.com/x",
+		},
+		{
+			raw:      "page.html?a=1",
+			base:     "https://example.com/articles/2024/",
+			expected: "example.com/articles/2024/page.html?a=1",
+		},
+	}
+
+	for _, c := range cases {
+		res, err := NormalizeURLWithBase(c.raw, c.base)
+		if err != nil {
+			t.Fatalf("%v for raw: %s base: %s", err, c.raw, c.base)
+		}
+		if res != c.expected {
+			t.Fatalf("\nexpected:\t%s\n\tactual: %s\n  for raw: %s base: %s", c.expected, res, c.raw, c.base)
+		}
+	}
+}
+
+func TestNormalizeURLWithBaseRejectsRelativeBase(t *testing.T) {
+	if _, err := NormalizeURLWithBase("/login", "/not-absolute"); err != ErrBaseNotAbs {
+		t.Fatalf("expected ErrBaseNotAbs, got %v", err)
+	}
+}
+
 type testCases struct {
 	orig       string
 	normalized string